@@ -0,0 +1,220 @@
+package modelcard
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+)
+
+// Cache is an on-disk cache of extracted modelcards, keyed by manifest
+// digest, so repeated runs over the same refs don't re-hit the registry for
+// the blob itself. It's deliberately simple: one JSON index plus one file per
+// cached modelcard under a blobs/ subdirectory, both safe to delete.
+//
+// mu serializes Get/Put/GC's index.json read-modify-write cycles: ExtractAll
+// runs extractions concurrently against the same cache dir (each Extract opens
+// its own Cache, but every Cache opened on one dir shares one mutex), and
+// without this two Puts racing on the same load-modify-save would silently
+// clobber one another's entry.
+type Cache struct {
+	dir string
+	mu  *sync.Mutex
+}
+
+// cacheLocks maps a cache dir to the mutex shared by every Cache opened on it.
+var cacheLocks sync.Map
+
+// DefaultCacheDir returns $XDG_CACHE_HOME/modelcards, falling back to
+// ~/.cache/modelcards when XDG_CACHE_HOME is unset.
+func DefaultCacheDir() (string, error) {
+	if base := os.Getenv("XDG_CACHE_HOME"); base != "" {
+		return filepath.Join(base, "modelcards"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache", "modelcards"), nil
+}
+
+// OpenCache opens (creating if necessary) the cache rooted at dir.
+func OpenCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "blobs"), 0755); err != nil {
+		return nil, err
+	}
+	mu, _ := cacheLocks.LoadOrStore(dir, &sync.Mutex{})
+	return &Cache{dir: dir, mu: mu.(*sync.Mutex)}, nil
+}
+
+// cacheEntry is one index.json record, keyed by "ref@manifestDigest".
+type cacheEntry struct {
+	ManifestDigest digest.Digest `json:"manifestDigest"`
+	FileName       string        `json:"fileName"`
+	BlobFile       string        `json:"blobFile"`
+	LastUsedAt     time.Time     `json:"lastUsedAt"`
+}
+
+// cacheIndex is index.json's shape: "ref@manifestDigest" -> cacheEntry.
+type cacheIndex map[string]cacheEntry
+
+func (c *Cache) indexPath() string {
+	return filepath.Join(c.dir, "index.json")
+}
+
+func (c *Cache) blobsDir() string {
+	return filepath.Join(c.dir, "blobs")
+}
+
+func (c *Cache) loadIndex() (cacheIndex, error) {
+	data, err := os.ReadFile(c.indexPath())
+	if os.IsNotExist(err) {
+		return cacheIndex{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	idx := cacheIndex{}
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+func (c *Cache) saveIndex(idx cacheIndex) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.indexPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.indexPath())
+}
+
+func cacheKey(ref string, manifestDigest digest.Digest) string {
+	return ref + "@" + manifestDigest.String()
+}
+
+// Get returns the cached modelcard for ref at manifestDigest, if present.
+func (c *Cache) Get(ref string, manifestDigest digest.Digest) (*ModelCard, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx, err := c.loadIndex()
+	if err != nil {
+		return nil, false, err
+	}
+	key := cacheKey(ref, manifestDigest)
+	entry, ok := idx[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(c.blobsDir(), entry.BlobFile))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	entry.LastUsedAt = time.Now()
+	idx[key] = entry
+	if err := c.saveIndex(idx); err != nil {
+		return nil, false, err
+	}
+
+	return &ModelCard{FileName: entry.FileName, Content: content}, true, nil
+}
+
+// Put stores mc for ref at manifestDigest. The blob file is named after
+// manifestDigest (not the ref), so refs that share a digest share one file.
+func (c *Cache) Put(ref string, manifestDigest digest.Digest, mc *ModelCard) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	blobFile := manifestDigest.Encoded() + filepath.Ext(mc.FileName)
+	if err := os.WriteFile(filepath.Join(c.blobsDir(), blobFile), mc.Content, 0644); err != nil {
+		return err
+	}
+
+	idx, err := c.loadIndex()
+	if err != nil {
+		return err
+	}
+	idx[cacheKey(ref, manifestDigest)] = cacheEntry{
+		ManifestDigest: manifestDigest,
+		FileName:       mc.FileName,
+		BlobFile:       blobFile,
+		LastUsedAt:     time.Now(),
+	}
+	return c.saveIndex(idx)
+}
+
+// GCResult reports what a GC pass removed (or, for a dry run, would remove).
+type GCResult struct {
+	RemovedEntries []string // index keys ("ref@manifestDigest")
+	RemovedBlobs   []string // blob file names under blobs/
+}
+
+// GC removes cache entries whose manifest digest isn't in liveDigests - the
+// mark set, built by the caller from the refs it still cares about - and then
+// sweeps any blob file under blobs/ that's no longer referenced by a
+// surviving entry (this also cleans up orphan blobs left behind by a crash).
+//
+// When olderThan > 0, an entry is only eligible for removal once it hasn't
+// been used (via Get or Put) for at least that long, even if it's not in
+// liveDigests. With dryRun set, nothing is deleted; GCResult still reports
+// what would have been.
+func (c *Cache) GC(liveDigests map[digest.Digest]bool, olderThan time.Duration, dryRun bool) (*GCResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	idx, err := c.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GCResult{}
+	keepBlobs := make(map[string]bool)
+	now := time.Now()
+
+	for key, entry := range idx {
+		if liveDigests[entry.ManifestDigest] {
+			keepBlobs[entry.BlobFile] = true
+			continue
+		}
+		if olderThan > 0 && now.Sub(entry.LastUsedAt) < olderThan {
+			keepBlobs[entry.BlobFile] = true
+			continue
+		}
+		result.RemovedEntries = append(result.RemovedEntries, key)
+		delete(idx, key)
+	}
+
+	dirEntries, err := os.ReadDir(c.blobsDir())
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range dirEntries {
+		if !keepBlobs[e.Name()] {
+			result.RemovedBlobs = append(result.RemovedBlobs, e.Name())
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	for _, blob := range result.RemovedBlobs {
+		if err := os.Remove(filepath.Join(c.blobsDir(), blob)); err != nil && !os.IsNotExist(err) {
+			return result, err
+		}
+	}
+	return result, c.saveIndex(idx)
+}
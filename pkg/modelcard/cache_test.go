@@ -0,0 +1,128 @@
+package modelcard
+
+import (
+	"testing"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+)
+
+func TestCacheGetPutPreservesFileName(t *testing.T) {
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenCache: %v", err)
+	}
+
+	ref := "example.com/modelcar-demo:1.0"
+	manifestDigest := digest.FromString("manifest")
+	mc := &ModelCard{FileName: "README.md", Content: []byte("# hello")}
+
+	if err := cache.Put(ref, manifestDigest, mc); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, hit, err := cache.Get(ref, manifestDigest)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !hit {
+		t.Fatalf("Get: expected a hit")
+	}
+	if got.FileName != "README.md" {
+		t.Fatalf("Get: FileName = %q, want the original in-layer path %q", got.FileName, "README.md")
+	}
+	if string(got.Content) != "# hello" {
+		t.Fatalf("Get: Content = %q, want %q", got.Content, "# hello")
+	}
+}
+
+func TestCacheGetMiss(t *testing.T) {
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenCache: %v", err)
+	}
+
+	_, hit, err := cache.Get("example.com/nope:1.0", digest.FromString("nope"))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if hit {
+		t.Fatalf("Get: expected a miss on an empty cache")
+	}
+}
+
+func TestCacheGCRemovesUnreferencedEntries(t *testing.T) {
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenCache: %v", err)
+	}
+
+	liveRef, liveDigest := "example.com/live:1.0", digest.FromString("live")
+	goneRef, goneDigest := "example.com/gone:1.0", digest.FromString("gone")
+	for ref, d := range map[string]digest.Digest{liveRef: liveDigest, goneRef: goneDigest} {
+		if err := cache.Put(ref, d, &ModelCard{FileName: "README.md", Content: []byte(ref)}); err != nil {
+			t.Fatalf("Put(%s): %v", ref, err)
+		}
+	}
+
+	result, err := cache.GC(map[digest.Digest]bool{liveDigest: true}, 0, false)
+	if err != nil {
+		t.Fatalf("GC: %v", err)
+	}
+	if len(result.RemovedEntries) != 1 || len(result.RemovedBlobs) != 1 {
+		t.Fatalf("GC: removed %d entries, %d blobs; want 1, 1", len(result.RemovedEntries), len(result.RemovedBlobs))
+	}
+
+	if _, hit, err := cache.Get(liveRef, liveDigest); err != nil || !hit {
+		t.Fatalf("Get(%s): hit=%v err=%v, want a surviving hit", liveRef, hit, err)
+	}
+	if _, hit, err := cache.Get(goneRef, goneDigest); err != nil || hit {
+		t.Fatalf("Get(%s): hit=%v err=%v, want it swept", goneRef, hit, err)
+	}
+}
+
+func TestCacheGCDryRunRemovesNothing(t *testing.T) {
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenCache: %v", err)
+	}
+
+	ref, d := "example.com/gone:1.0", digest.FromString("gone")
+	if err := cache.Put(ref, d, &ModelCard{FileName: "README.md", Content: []byte("x")}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	result, err := cache.GC(nil, 0, true)
+	if err != nil {
+		t.Fatalf("GC: %v", err)
+	}
+	if len(result.RemovedEntries) != 1 {
+		t.Fatalf("GC dry run: reported %d removed entries, want 1", len(result.RemovedEntries))
+	}
+
+	if _, hit, err := cache.Get(ref, d); err != nil || !hit {
+		t.Fatalf("Get: hit=%v err=%v, want a dry run to leave the entry in place", hit, err)
+	}
+}
+
+func TestCacheGCRespectsOlderThan(t *testing.T) {
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenCache: %v", err)
+	}
+
+	ref, d := "example.com/recent:1.0", digest.FromString("recent")
+	if err := cache.Put(ref, d, &ModelCard{FileName: "README.md", Content: []byte("x")}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	// Not in the live set, but used just now - an hour-long grace period
+	// should keep it around.
+	result, err := cache.GC(nil, time.Hour, false)
+	if err != nil {
+		t.Fatalf("GC: %v", err)
+	}
+	if len(result.RemovedEntries) != 0 {
+		t.Fatalf("GC: removed %d entries within the grace period, want 0", len(result.RemovedEntries))
+	}
+}
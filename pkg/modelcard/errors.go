@@ -0,0 +1,31 @@
+package modelcard
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned (wrapped in an *Error) when a ref has no layer
+// annotated as a modelcard, or that layer contains no .md file.
+var ErrNotFound = errors.New("no modelcard found")
+
+// ErrAmbiguous is returned (wrapped in an *Error) when a modelcard layer
+// contains more than one .md file, so there is no single file to return.
+var ErrAmbiguous = errors.New("modelcard layer contains more than one .md file")
+
+// Error records which ref and which step of extraction failed, so callers
+// fanning out over many refs (ExtractAll) can report failures without parsing
+// log lines.
+type Error struct {
+	Ref string // the manifestRef extraction was attempted for
+	Op  string // e.g. "parse reference", "get manifest", "resolve platform instance"
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("modelcard: %s: %s: %v", e.Ref, e.Op, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,106 @@
+package modelcard
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+
+	"github.com/containers/image/v5/types"
+	digest "github.com/opencontainers/go-digest"
+)
+
+// Extract fetches manifestRef and returns its modelcard. If opts.AllPlatforms
+// is set and the ref resolves to a manifest list / image index, every child
+// manifest is scanned and the first modelcard found is returned.
+//
+// When opts.CacheDir is set, a hit there skips the registry fetch of the
+// modelcard layer entirely (the manifest is still fetched, to know the
+// current digest to look up).
+func Extract(ctx context.Context, manifestRef string, opts Options) (*ModelCard, error) {
+	applyDefaults(&opts)
+
+	var cache *Cache
+	if opts.CacheDir != "" {
+		c, err := OpenCache(opts.CacheDir)
+		if err != nil {
+			return nil, &Error{Ref: manifestRef, Op: "open cache", Err: err}
+		}
+		cache = c
+	}
+
+	if !opts.AllPlatforms {
+		src, layers, manifestDigest, err := fetchManifestSrcAndLayers(ctx, manifestRef, opts.SystemContext, opts.PolicyPath)
+		if err != nil {
+			return nil, err
+		}
+		defer src.Close()
+		return extractFromLayers(ctx, src, manifestRef, "", manifestDigest, layers, opts, cache)
+	}
+
+	src, layersByDigest, err := fetchAllPlatformManifestsAndLayers(ctx, manifestRef, opts.SystemContext, opts.PolicyPath)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// layersByDigest is a map; iterate in a stable order so which platform's
+	// modelcard wins (when more than one carries one) doesn't depend on Go's
+	// randomized map iteration.
+	instanceDigests := make([]digest.Digest, 0, len(layersByDigest))
+	for instanceDigest := range layersByDigest {
+		instanceDigests = append(instanceDigests, instanceDigest)
+	}
+	sort.Slice(instanceDigests, func(i, j int) bool { return instanceDigests[i] < instanceDigests[j] })
+
+	for _, instanceDigest := range instanceDigests {
+		mc, err := extractFromLayers(ctx, src, manifestRef, instanceDigest, instanceDigest, layersByDigest[instanceDigest], opts, cache)
+		if err == nil {
+			return mc, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, &Error{Ref: manifestRef, Op: "extract", Err: ErrNotFound}
+}
+
+// extractFromLayers scans layers for a modelcard, consulting/populating cache
+// (keyed by manifestDigest) and writing to opts.OutputDir when set.
+// instanceDigest tags the returned ModelCard and, when non-empty, names its
+// output subdirectory; Extract passes it only in AllPlatforms mode and leaves
+// it empty otherwise.
+func extractFromLayers(ctx context.Context, src types.ImageSource, manifestRef string, instanceDigest, manifestDigest digest.Digest, layers []types.BlobInfo, opts Options, cache *Cache) (*ModelCard, error) {
+	if cache != nil {
+		if mc, hit, err := cache.Get(manifestRef, manifestDigest); err != nil {
+			log.Printf("  Cache lookup failed for %s@%s: %v\n", manifestRef, manifestDigest, err)
+		} else if hit {
+			log.Printf("  Cache hit for %s@%s, skipping layer fetch\n", manifestRef, manifestDigest)
+			mc.InstanceDigest = instanceDigest
+			return mc, nil
+		}
+	}
+
+	mc, err := scanLayersForModelCard(ctx, layers, src, manifestRef)
+	if err != nil {
+		return nil, err
+	}
+	mc.InstanceDigest = instanceDigest
+
+	if cache != nil {
+		if err := cache.Put(manifestRef, manifestDigest, mc); err != nil {
+			log.Printf("  Failed to cache modelcard for %s@%s: %v\n", manifestRef, manifestDigest, err)
+		}
+	}
+
+	if opts.OutputDir != "" {
+		dirRef := manifestRef
+		if instanceDigest != "" {
+			dirRef = manifestRef + "@" + instanceDigest.String()
+		}
+		if err := writeModelCard(opts.OutputDir, dirRef, mc.FileName, mc.Content); err != nil {
+			return nil, &Error{Ref: manifestRef, Op: "write modelcard", Err: err}
+		}
+	}
+	return mc, nil
+}
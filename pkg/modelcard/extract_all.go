@@ -0,0 +1,45 @@
+package modelcard
+
+import (
+	"context"
+	"sync"
+)
+
+// ExtractAll extracts modelcards for refs concurrently, bounded by
+// opts.Concurrency, and streams results back as they complete. The returned
+// channel is closed once every ref has produced a Result; it should be
+// drained even if ctx is canceled partway through.
+func ExtractAll(ctx context.Context, refs []string, opts Options) (<-chan Result, error) {
+	applyDefaults(&opts)
+
+	results := make(chan Result)
+
+	go func() {
+		defer close(results)
+
+		var wg sync.WaitGroup
+		semaphore := make(chan struct{}, opts.Concurrency)
+
+		for _, ref := range refs {
+			select {
+			case <-ctx.Done():
+				results <- Result{Ref: ref, Err: ctx.Err()}
+				continue
+			case semaphore <- struct{}{}:
+			}
+
+			wg.Add(1)
+			go func(ref string) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+
+				mc, err := Extract(ctx, ref, opts)
+				results <- Result{Ref: ref, ModelCard: mc, Err: err}
+			}(ref)
+		}
+
+		wg.Wait()
+	}()
+
+	return results, nil
+}
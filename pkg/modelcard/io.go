@@ -0,0 +1,48 @@
+package modelcard
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// writeModelCard writes an extracted modelcard file under baseDir/<sanitized ref>/,
+// preserving the file's own path from within the layer.
+//
+// fileName comes straight from a tar entry name inside a registry-controlled
+// layer, so it's untrusted: an entry like "../../../../tmp/pwned.md" must not
+// be allowed to write outside outputDir (a tar-slip). The joined path is
+// rejected unless it stays under outputDir.
+func writeModelCard(baseDir, ref, fileName string, content []byte) error {
+	outputDir := filepath.Join(baseDir, sanitizeRef(ref))
+
+	outputFilePath := filepath.Join(outputDir, fileName)
+	if outputFilePath != outputDir && !strings.HasPrefix(outputFilePath, outputDir+string(filepath.Separator)) {
+		return fmt.Errorf("modelcard file name %q escapes output directory", fileName)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputFilePath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFilePath, content, 0644); err != nil {
+		return err
+	}
+
+	log.Printf("  Successfully wrote modelcard content to: %s\n", outputFilePath)
+	return nil
+}
+
+var (
+	invalidPathChars    = regexp.MustCompile(`[\/\\:*?"<>|]`)
+	repeatedUnderscores = regexp.MustCompile(`_+`)
+)
+
+// sanitizeRef turns a manifest ref (possibly with an "@sha256:..." instance
+// suffix) into a valid directory name.
+func sanitizeRef(ref string) string {
+	sanitized := invalidPathChars.ReplaceAllString(ref, "_")
+	sanitized = repeatedUnderscores.ReplaceAllString(sanitized, "_")
+	return strings.Trim(sanitized, "_")
+}
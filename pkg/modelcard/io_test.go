@@ -0,0 +1,33 @@
+package modelcard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteModelCardRejectsPathTraversal(t *testing.T) {
+	baseDir := t.TempDir()
+
+	err := writeModelCard(baseDir, "example.com/modelcar-demo:1.0", "../../../../tmp/pwned.md", []byte("x"))
+	if err == nil {
+		t.Fatalf("writeModelCard: expected an error for a tar entry name escaping outputDir")
+	}
+}
+
+func TestWriteModelCardWritesUnderOutputDir(t *testing.T) {
+	baseDir := t.TempDir()
+
+	if err := writeModelCard(baseDir, "example.com/modelcar-demo:1.0", "docs/README.md", []byte("# hello")); err != nil {
+		t.Fatalf("writeModelCard: %v", err)
+	}
+
+	want := filepath.Join(baseDir, sanitizeRef("example.com/modelcar-demo:1.0"), "docs", "README.md")
+	content, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", want, err)
+	}
+	if string(content) != "# hello" {
+		t.Fatalf("content = %q, want %q", content, "# hello")
+	}
+}
@@ -0,0 +1,221 @@
+package modelcard
+
+import (
+	"context"
+	"log"
+	"runtime"
+
+	"github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/types"
+	digest "github.com/opencontainers/go-digest"
+)
+
+// fetchManifestSrcAndLayers resolves manifestRef to an image source and its
+// layers, along with the digest of the manifest those layers belong to (the
+// ref's own manifest digest, or the chosen child's, for a list/index). When
+// the ref is a manifest list / image index, it picks the single instance
+// matching sys (falling back to runtime.GOARCH/GOOS) and returns that
+// instance's layers.
+func fetchManifestSrcAndLayers(ctx context.Context, manifestRef string, sys *types.SystemContext, policyPath string) (types.ImageSource, []types.BlobInfo, digest.Digest, error) {
+	log.Printf("Parsing reference %q...\n", manifestRef)
+	ref, err := parseImageReference(manifestRef)
+	if err != nil {
+		return nil, nil, "", &Error{Ref: manifestRef, Op: "parse reference", Err: err}
+	}
+
+	log.Printf("Creating image source...\n")
+	src, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, nil, "", &Error{Ref: manifestRef, Op: "create image source", Err: err}
+	}
+
+	manifestBlob, manifestType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		src.Close()
+		return nil, nil, "", &Error{Ref: manifestRef, Op: "get manifest", Err: err}
+	}
+	log.Printf("Manifest type: %s, size: %d bytes\n", manifestType, len(manifestBlob))
+
+	manifestBlob, instanceDigest, err := resolvePlatformInstance(ctx, src, manifestBlob, manifestType, sys)
+	if err != nil {
+		src.Close()
+		return nil, nil, "", &Error{Ref: manifestRef, Op: "resolve platform instance", Err: err}
+	}
+	if instanceDigest != nil {
+		log.Printf("Selected platform manifest %s\n", *instanceDigest)
+	}
+
+	resolvedDigest, err := manifest.Digest(manifestBlob)
+	if err != nil {
+		src.Close()
+		return nil, nil, "", &Error{Ref: manifestRef, Op: "compute manifest digest", Err: err}
+	}
+
+	unparsed := image.UnparsedInstance(src, instanceDigest)
+	if err := checkImageAllowed(ctx, sys, policyPath, unparsed); err != nil {
+		src.Close()
+		return nil, nil, "", &Error{Ref: manifestRef, Op: "check signature policy", Err: err}
+	}
+
+	img, err := image.FromUnparsedImage(ctx, sys, unparsed)
+	if err != nil {
+		src.Close()
+		return nil, nil, "", &Error{Ref: manifestRef, Op: "create image", Err: err}
+	}
+
+	layers := img.LayerInfos()
+	log.Printf("Number of layers: %d\n", len(layers))
+
+	return src, layers, resolvedDigest, nil
+}
+
+// ResolveManifestDigest fetches the manifest digest that extracting
+// manifestRef with the given sys would cache against, without reading any
+// layers. For a plain manifest that's just the manifest's own digest; for a
+// manifest list / image index it's the digest of the platform instance
+// choosePlatformInstance would pick - the same resolution
+// fetchManifestSrcAndLayers performs - so a GC mark set built from this lines
+// up with how extractFromLayers keys cache entries for extractions made
+// without AllPlatforms. Entries cached in AllPlatforms mode under another
+// platform's digest are not in it. It's cheap enough to call once per ref to
+// build that mark set.
+func ResolveManifestDigest(ctx context.Context, manifestRef string, sys *types.SystemContext) (digest.Digest, error) {
+	ref, err := parseImageReference(manifestRef)
+	if err != nil {
+		return "", &Error{Ref: manifestRef, Op: "parse reference", Err: err}
+	}
+	src, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return "", &Error{Ref: manifestRef, Op: "create image source", Err: err}
+	}
+	defer src.Close()
+
+	manifestBlob, manifestType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return "", &Error{Ref: manifestRef, Op: "get manifest", Err: err}
+	}
+
+	manifestBlob, _, err = resolvePlatformInstance(ctx, src, manifestBlob, manifestType, sys)
+	if err != nil {
+		return "", &Error{Ref: manifestRef, Op: "resolve platform instance", Err: err}
+	}
+
+	d, err := manifest.Digest(manifestBlob)
+	if err != nil {
+		return "", &Error{Ref: manifestRef, Op: "compute manifest digest", Err: err}
+	}
+	return d, nil
+}
+
+// resolvePlatformInstance, given manifestRef's top-level manifest, returns
+// the manifest blob to hash/read layers from and the digest of the chosen
+// child instance (nil for a non-list manifest): the top-level manifest
+// unchanged when manifestType isn't a list/image index, or the single child
+// instance matching sys (via choosePlatformInstance) otherwise.
+func resolvePlatformInstance(ctx context.Context, src types.ImageSource, manifestBlob []byte, manifestType string, sys *types.SystemContext) ([]byte, *digest.Digest, error) {
+	if !manifest.MIMETypeIsMultiImage(manifestType) {
+		return manifestBlob, nil, nil
+	}
+
+	list, err := manifest.ListFromBlob(manifestBlob, manifestType)
+	if err != nil {
+		return nil, nil, err
+	}
+	chosen, err := choosePlatformInstance(list, sys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	platformBlob, _, err := src.GetManifest(ctx, &chosen)
+	if err != nil {
+		return nil, nil, err
+	}
+	return platformBlob, &chosen, nil
+}
+
+// fetchAllPlatformManifestsAndLayers resolves every child manifest of a
+// manifest list / image index and returns each one's layers keyed by the
+// child's own manifest digest. For a plain (single-platform) manifest it
+// returns that one manifest's layers keyed by its own digest.
+func fetchAllPlatformManifestsAndLayers(ctx context.Context, manifestRef string, sys *types.SystemContext, policyPath string) (types.ImageSource, map[digest.Digest][]types.BlobInfo, error) {
+	ref, err := parseImageReference(manifestRef)
+	if err != nil {
+		return nil, nil, &Error{Ref: manifestRef, Op: "parse reference", Err: err}
+	}
+
+	src, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return nil, nil, &Error{Ref: manifestRef, Op: "create image source", Err: err}
+	}
+
+	manifestBlob, manifestType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		src.Close()
+		return nil, nil, &Error{Ref: manifestRef, Op: "get manifest", Err: err}
+	}
+
+	layersByDigest := make(map[digest.Digest][]types.BlobInfo)
+
+	if !manifest.MIMETypeIsMultiImage(manifestType) {
+		d, err := manifest.Digest(manifestBlob)
+		if err != nil {
+			src.Close()
+			return nil, nil, &Error{Ref: manifestRef, Op: "compute manifest digest", Err: err}
+		}
+		unparsed := image.UnparsedInstance(src, nil)
+		if err := checkImageAllowed(ctx, sys, policyPath, unparsed); err != nil {
+			src.Close()
+			return nil, nil, &Error{Ref: manifestRef, Op: "check signature policy", Err: err}
+		}
+		img, err := image.FromUnparsedImage(ctx, sys, unparsed)
+		if err != nil {
+			src.Close()
+			return nil, nil, &Error{Ref: manifestRef, Op: "create image", Err: err}
+		}
+		layersByDigest[d] = img.LayerInfos()
+		return src, layersByDigest, nil
+	}
+
+	log.Printf("Manifest is a list (%s), scanning every platform instance...\n", manifestType)
+	list, err := manifest.ListFromBlob(manifestBlob, manifestType)
+	if err != nil {
+		src.Close()
+		return nil, nil, &Error{Ref: manifestRef, Op: "parse manifest list", Err: err}
+	}
+
+	for _, instanceDigest := range list.Instances() {
+		d := instanceDigest
+		unparsed := image.UnparsedInstance(src, &d)
+		if err := checkImageAllowed(ctx, sys, policyPath, unparsed); err != nil {
+			log.Printf("  Skipping instance %s: rejected by signature policy: %v\n", d, err)
+			continue
+		}
+		img, err := image.FromUnparsedImage(ctx, sys, unparsed)
+		if err != nil {
+			log.Printf("  Skipping instance %s: %v\n", d, err)
+			continue
+		}
+		layersByDigest[d] = img.LayerInfos()
+	}
+	return src, layersByDigest, nil
+}
+
+// choosePlatformInstance picks the child manifest digest matching sys out of a
+// manifest list / image index, defaulting unset ArchitectureChoice/OSChoice to
+// the host's runtime.GOARCH/GOOS so a nil or zero-value SystemContext still
+// resolves to something runnable locally.
+func choosePlatformInstance(list manifest.List, sys *types.SystemContext) (digest.Digest, error) {
+	platformSys := types.SystemContext{}
+	if sys != nil {
+		platformSys = *sys
+	}
+	if platformSys.ArchitectureChoice == "" {
+		platformSys.ArchitectureChoice = runtime.GOARCH
+	}
+	if platformSys.OSChoice == "" {
+		platformSys.OSChoice = runtime.GOOS
+	}
+
+	return list.ChooseInstance(&platformSys)
+}
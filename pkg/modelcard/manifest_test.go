@@ -0,0 +1,57 @@
+package modelcard
+
+import (
+	"testing"
+
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/types"
+)
+
+const testImageIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "sha256:1111111111111111111111111111111111111111111111111111111111111a",
+      "size": 100,
+      "platform": {"architecture": "amd64", "os": "linux"}
+    },
+    {
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "digest": "sha256:2222222222222222222222222222222222222222222222222222222222222b",
+      "size": 100,
+      "platform": {"architecture": "arm64", "os": "linux"}
+    }
+  ]
+}`
+
+func testImageIndexList(t *testing.T) manifest.List {
+	t.Helper()
+	list, err := manifest.ListFromBlob([]byte(testImageIndex), "application/vnd.oci.image.index.v1+json")
+	if err != nil {
+		t.Fatalf("ListFromBlob: %v", err)
+	}
+	return list
+}
+
+func TestChoosePlatformInstanceMatchesSystemContext(t *testing.T) {
+	list := testImageIndexList(t)
+
+	got, err := choosePlatformInstance(list, &types.SystemContext{ArchitectureChoice: "arm64", OSChoice: "linux"})
+	if err != nil {
+		t.Fatalf("choosePlatformInstance: %v", err)
+	}
+	want := "sha256:2222222222222222222222222222222222222222222222222222222222222b"
+	if got.String() != want {
+		t.Fatalf("choosePlatformInstance = %s, want %s", got, want)
+	}
+}
+
+func TestChoosePlatformInstanceNoMatch(t *testing.T) {
+	list := testImageIndexList(t)
+
+	if _, err := choosePlatformInstance(list, &types.SystemContext{ArchitectureChoice: "riscv64", OSChoice: "linux"}); err == nil {
+		t.Fatalf("choosePlatformInstance: expected an error for a platform not in the index")
+	}
+}
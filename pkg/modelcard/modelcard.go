@@ -0,0 +1,79 @@
+// Package modelcard extracts the modelcard (a single Markdown file) out of a
+// "modelcar" OCI image: a container image whose layers carry model artifacts,
+// one of which is annotated io.opendatahub.modelcar.layer.type=modelcard.
+//
+// It wraps github.com/containers/image/v5 so callers - CLIs, controllers,
+// anything embedding this - never have to deal with image sources, manifest
+// lists or layer blobs directly.
+package modelcard
+
+import (
+	"github.com/containers/image/v5/types"
+	digest "github.com/opencontainers/go-digest"
+)
+
+// ModelCard is a single extracted modelcard file.
+type ModelCard struct {
+	// FileName is the path of the .md file as it appeared inside the layer.
+	FileName string
+	// Content is the raw Markdown content of the file.
+	Content []byte
+	// InstanceDigest is set only when Options.AllPlatforms is set: it is then
+	// the digest of the manifest the modelcard was found in (a child's digest
+	// for a manifest list / image index, the manifest's own digest otherwise).
+	// Without AllPlatforms it is empty, even when the ref resolved to a list.
+	InstanceDigest digest.Digest
+}
+
+// Options configures an extraction. The zero value is valid: it extracts from
+// the host's own platform, does not write anything to disk, and uses an empty
+// types.SystemContext (ambient credentials, default registries.conf, etc).
+type Options struct {
+	// SystemContext configures registry access (credentials, TLS, platform
+	// selection, transports). Defaults to &types.SystemContext{} when nil.
+	SystemContext *types.SystemContext
+
+	// Concurrency bounds how many refs ExtractAll processes at once.
+	// Defaults to 5 when <= 0.
+	Concurrency int
+
+	// OutputDir, when non-empty, causes successfully extracted modelcards to
+	// also be written to disk under OutputDir/<sanitized ref>/<FileName>
+	// (with AllPlatforms, the ref is suffixed with "@" and the InstanceDigest).
+	OutputDir string
+
+	// AllPlatforms, when the ref resolves to a manifest list / image index,
+	// scans every child manifest for a modelcard instead of only the one
+	// matching SystemContext's (or the host's) platform.
+	AllPlatforms bool
+
+	// PolicyPath is the containers/image signature policy.json to verify the
+	// image against before any layer is read. Empty uses signature.DefaultPolicy
+	// (/etc/containers/policy.json, or SystemContext.SignaturePolicyPath), or,
+	// if no such file exists, accepts any image unverified.
+	PolicyPath string
+
+	// CacheDir, when non-empty, caches extracted modelcards on disk keyed by
+	// manifest digest, so repeated calls for a ref that hasn't changed skip
+	// fetching the modelcard layer (the manifest is still fetched from the
+	// registry). See DefaultCacheDir for a sensible default.
+	CacheDir string
+}
+
+// Result is one outcome of ExtractAll: either a ModelCard or an error, tagged
+// with the ref it came from so callers processing many refs concurrently can
+// tell them apart.
+type Result struct {
+	Ref       string
+	ModelCard *ModelCard
+	Err       error
+}
+
+func applyDefaults(opts *Options) {
+	if opts.SystemContext == nil {
+		opts.SystemContext = &types.SystemContext{}
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 5
+	}
+}
@@ -0,0 +1,64 @@
+package modelcard
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/containers/image/v5/signature"
+	"github.com/containers/image/v5/types"
+)
+
+// checkImageAllowed verifies unparsed against a signature policy before any of
+// its layers are read, so a modelcard is never extracted from an image the
+// policy would reject (missing/invalid cosign or GPG signature, etc).
+//
+// policyPath selects the policy file; an empty policyPath uses
+// signature.DefaultPolicy (normally /etc/containers/policy.json, or
+// sys.SignaturePolicyPath when set), falling back to accepting any image if
+// no such policy file exists - see loadPolicy.
+func checkImageAllowed(ctx context.Context, sys *types.SystemContext, policyPath string, unparsed types.UnparsedImage) error {
+	policy, err := loadPolicy(sys, policyPath)
+	if err != nil {
+		return fmt.Errorf("load signature policy: %w", err)
+	}
+
+	policyCtx, err := signature.NewPolicyContext(policy)
+	if err != nil {
+		return fmt.Errorf("create policy context: %w", err)
+	}
+	defer policyCtx.Destroy()
+
+	allowed, err := policyCtx.IsRunningImageAllowed(ctx, unparsed)
+	if !allowed {
+		if err == nil {
+			err = fmt.Errorf("image rejected by signature policy")
+		}
+		return err
+	}
+	return err
+}
+
+// loadPolicy loads the signature policy to verify against. With an explicit
+// policyPath, a missing/invalid file is an error - the caller asked for
+// verification and it failed. Without one, a missing system policy.json
+// (the common case: this tool ships no policy.json of its own, and most
+// machines running it won't have /etc/containers/policy.json either) falls
+// back to accepting any image, rather than every extraction failing closed
+// with no way to opt out short of hand-authoring a policy file.
+func loadPolicy(sys *types.SystemContext, policyPath string) (*signature.Policy, error) {
+	if policyPath != "" {
+		return signature.NewPolicyFromFile(policyPath)
+	}
+
+	policy, err := signature.DefaultPolicy(sys)
+	if err == nil {
+		return policy, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	log.Printf("No signature policy configured (%v); accepting images unverified - pass -policy to require signatures", err)
+	return &signature.Policy{Default: signature.PolicyRequirements{signature.NewPRInsecureAcceptAnything()}}, nil
+}
@@ -0,0 +1,18 @@
+package modelcard
+
+import (
+	"github.com/containers/image/v5/transports/alltransports"
+	"github.com/containers/image/v5/types"
+)
+
+// parseImageReference resolves manifestRef to a types.ImageReference via any
+// registered containers/image transport (docker://, oci:, oci-archive:,
+// docker-archive:, containers-storage:, dir:, ...). For backwards
+// compatibility with bare "registry/repo:tag" refs (no transport prefix), it
+// falls back to the docker:// transport when ParseImageName can't find one.
+func parseImageReference(manifestRef string) (types.ImageReference, error) {
+	if ref, err := alltransports.ParseImageName(manifestRef); err == nil {
+		return ref, nil
+	}
+	return alltransports.ParseImageName("docker://" + manifestRef)
+}
@@ -0,0 +1,101 @@
+package modelcard
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"strings"
+
+	blobinfocachememory "github.com/containers/image/v5/pkg/blobinfocache/memory"
+	"github.com/containers/image/v5/pkg/compression"
+	"github.com/containers/image/v5/types"
+)
+
+// scanLayersForModelCard looks for the layer annotated
+// io.opendatahub.modelcar.layer.type=modelcard and returns its single .md
+// file. It returns ErrNotFound if no such layer exists (or the layer has no
+// .md file), or ErrAmbiguous if the layer has more than one.
+func scanLayersForModelCard(ctx context.Context, layers []types.BlobInfo, src types.ImageSource, ref string) (*ModelCard, error) {
+	for i, layer := range layers {
+		log.Printf("Layer %d: digest=%s mediaType=%s size=%d annotations=%v\n", i+1, layer.Digest, layer.MediaType, layer.Size, layer.Annotations)
+
+		layerType, exists := layer.Annotations["io.opendatahub.modelcar.layer.type"]
+		if !exists || layerType != "modelcard" {
+			continue
+		}
+		log.Printf("  Found modelcard layer, digest: %s\n", layer.Digest)
+
+		mc, err := readModelCardFromLayerBlob(ctx, src, layer)
+		if e, ok := err.(*Error); ok && e.Ref == "" {
+			e.Ref = ref
+		}
+		return mc, err
+	}
+
+	return nil, &Error{Ref: ref, Op: "scan layers", Err: ErrNotFound}
+}
+
+// readModelCardFromLayerBlob downloads the full layer blob and reads its .md
+// file out of the tar stream.
+//
+// Decompression is sniffed from the stream's magic bytes via
+// compression.AutoDecompress rather than matching "+gzip" on the media type,
+// so this also handles OCI zstd layers (application/vnd.oci.image.layer.v1.tar+zstd)
+// and zstd:chunked layers, neither of which contain "gzip" anywhere in their
+// media type.
+func readModelCardFromLayerBlob(ctx context.Context, src types.ImageSource, layer types.BlobInfo) (*ModelCard, error) {
+	layerBlob, _, err := src.GetBlob(ctx, types.BlobInfo{Digest: layer.Digest}, blobinfocachememory.New())
+	if err != nil {
+		return nil, &Error{Op: "get modelcard layer blob", Err: err}
+	}
+	defer layerBlob.Close()
+
+	reader, isCompressed, err := compression.AutoDecompress(layerBlob)
+	if err != nil {
+		return nil, &Error{Op: "detect layer compression", Err: err}
+	}
+	defer reader.Close()
+	if isCompressed {
+		log.Printf("  Layer is compressed, decompressing...\n")
+	}
+
+	tr := tar.NewReader(reader)
+	var mdFileCount int
+	var fileName string
+	var content []byte
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &Error{Op: "read tar", Err: err}
+		}
+
+		if !strings.HasSuffix(header.Name, ".md") {
+			if _, err := io.Copy(io.Discard, tr); err != nil {
+				return nil, &Error{Op: "skip tar entry " + header.Name, Err: err}
+			}
+			continue
+		}
+
+		mdFileCount++
+		if mdFileCount > 1 {
+			return nil, &Error{Op: "scan layer", Err: ErrAmbiguous}
+		}
+		fileName = header.Name
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, tr); err != nil {
+			return nil, &Error{Op: "read tar entry " + header.Name, Err: err}
+		}
+		content = buf.Bytes()
+	}
+
+	if mdFileCount == 0 {
+		return nil, &Error{Op: "scan layer", Err: ErrNotFound}
+	}
+	return &ModelCard{FileName: fileName, Content: content}, nil
+}